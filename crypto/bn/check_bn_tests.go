@@ -16,6 +16,7 @@ package main
 
 import (
 	"bufio"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"io"
@@ -28,6 +29,23 @@ type test struct {
 	LineNumber int
 	Type       string
 	Values     map[string]*big.Int
+	Lists      map[string][]*big.Int
+}
+
+// keyBases maps keys whose values are not encoded in hexadecimal to the base
+// they are encoded in.
+var keyBases = map[string]int{
+	"DecVal": 10,
+}
+
+// listKeys contains keys whose values are comma-separated lists. They are
+// stored in Lists rather than Values.
+var listKeys = map[string]bool{
+	"Bases":         true,
+	"Exponents":     true,
+	"ResidueSet":    true,
+	"ResidueSystem": true,
+	"Values":        true,
 }
 
 type testScanner struct {
@@ -59,15 +77,42 @@ func (s *testScanner) addAttribute(line string) (key string, ok bool) {
 	key = strings.TrimSpace(fields[0])
 	value := strings.TrimSpace(fields[1])
 
-	valueInt, ok := new(big.Int).SetString(value, 16)
+	base, ok := keyBases[key]
 	if !ok {
-		s.setError(fmt.Errorf("could not parse %q", value))
-		return "", false
+		base = 16
 	}
+
 	if _, dup := s.test.Values[key]; dup {
 		s.setError(fmt.Errorf("duplicate key %q", key))
 		return "", false
 	}
+	if _, dup := s.test.Lists[key]; dup {
+		s.setError(fmt.Errorf("duplicate key %q", key))
+		return "", false
+	}
+
+	if listKeys[key] {
+		list := []*big.Int{}
+		if len(value) != 0 {
+			for _, elem := range strings.Split(value, ",") {
+				elem = strings.TrimSpace(elem)
+				elemInt, ok := new(big.Int).SetString(elem, base)
+				if !ok {
+					s.setError(fmt.Errorf("could not parse %q", elem))
+					return "", false
+				}
+				list = append(list, elemInt)
+			}
+		}
+		s.test.Lists[key] = list
+		return key, true
+	}
+
+	valueInt, ok := new(big.Int).SetString(value, base)
+	if !ok {
+		s.setError(fmt.Errorf("could not parse %q", value))
+		return "", false
+	}
 	s.test.Values[key] = valueInt
 	return key, true
 }
@@ -75,6 +120,7 @@ func (s *testScanner) addAttribute(line string) (key string, ok bool) {
 func (s *testScanner) Scan() bool {
 	s.test = test{
 		Values: make(map[string]*big.Int),
+		Lists:  make(map[string][]*big.Int),
 	}
 
 	// Scan until the first attribute.
@@ -129,13 +175,23 @@ func checkKeys(t test, keys ...string) bool {
 	var foundErrors bool
 
 	for _, k := range keys {
-		if _, ok := t.Values[k]; !ok {
+		_, isValue := t.Values[k]
+		_, isList := t.Lists[k]
+		if !isValue && !isList {
 			fmt.Fprintf(os.Stderr, "Line %d: missing key %q.\n", t.LineNumber, k)
 			foundErrors = true
 		}
 	}
 
+	allKeys := make([]string, 0, len(t.Values)+len(t.Lists))
 	for k, _ := range t.Values {
+		allKeys = append(allKeys, k)
+	}
+	for k, _ := range t.Lists {
+		allKeys = append(allKeys, k)
+	}
+
+	for _, k := range allKeys {
 		var found bool
 		for _, k2 := range keys {
 			if k == k2 {
@@ -158,6 +214,476 @@ func checkResult(t test, expr, key string, r *big.Int) {
 	}
 }
 
+// checkSmallValue returns the value of |key| as a uint if it is in [0, max].
+// Otherwise, it reports an error and returns false.
+func checkSmallValue(t test, key string, max uint64) (uint, bool) {
+	v := t.Values[key]
+	if v.Sign() < 0 || !v.IsUint64() || v.Uint64() > max {
+		fmt.Fprintf(os.Stderr, "Line %d: %s must be in [0, %d].\n", t.LineNumber, key, max)
+		return 0, false
+	}
+	return uint(v.Uint64()), true
+}
+
+// checkWordBits returns the value of the WordBits key if it is 32 or 64.
+// Otherwise, it reports an error and returns false.
+func checkWordBits(t test) (uint, bool) {
+	wordBits := t.Values["WordBits"]
+	if !wordBits.IsUint64() || (wordBits.Uint64() != 32 && wordBits.Uint64() != 64) {
+		fmt.Fprintf(os.Stderr, "Line %d: WordBits must be 32 or 64.\n", t.LineNumber)
+		return 0, false
+	}
+	return uint(wordBits.Uint64()), true
+}
+
+// checkExponent reports an error and returns false unless |key| is
+// non-negative and at most |maxBits| bits long.
+func checkExponent(t test, key string, maxBits int) bool {
+	v := t.Values[key]
+	if v.Sign() < 0 || v.BitLen() > maxBits {
+		fmt.Fprintf(os.Stderr, "Line %d: %s must be non-negative and at most %d bits.\n", t.LineNumber, key, maxBits)
+		return false
+	}
+	return true
+}
+
+// checkPositive reports an error and returns false unless |key| is positive.
+func checkPositive(t test, key string) bool {
+	if t.Values[key].Sign() <= 0 {
+		fmt.Fprintf(os.Stderr, "Line %d: %s must be positive.\n", t.LineNumber, key)
+		return false
+	}
+	return true
+}
+
+// montgomeryReduce returns T * R^-1 (mod M), where R = 2^rBits, using
+// Montgomery's REDC algorithm. M must be odd and less than R, and T must be in
+// [0, R * M).
+func montgomeryReduce(t, m *big.Int, rBits uint) *big.Int {
+	r := new(big.Int).Lsh(big.NewInt(1), rBits)
+	rMask := new(big.Int).Sub(r, big.NewInt(1))
+
+	// n0 = -M^-1 (mod R).
+	n0 := new(big.Int).ModInverse(m, r)
+	n0.Sub(r, n0)
+
+	u := new(big.Int).And(t, rMask)
+	u.Mul(u, n0)
+	u.And(u, rMask)
+
+	ret := new(big.Int).Mul(u, m)
+	ret.Add(ret, t)
+	ret.Rsh(ret, rBits)
+	if ret.Cmp(m) >= 0 {
+		ret.Sub(ret, m)
+	}
+	return ret
+}
+
+// checkMontModulus reports an error and returns false unless M is positive and
+// odd, as Montgomery reduction requires.
+func checkMontModulus(t test) bool {
+	m := t.Values["M"]
+	if m.Sign() <= 0 || m.Bit(0) != 1 {
+		fmt.Fprintf(os.Stderr, "Line %d: M must be positive and odd.\n", t.LineNumber)
+		return false
+	}
+	return true
+}
+
+// checkMontRadix returns log2 of the R key if M is a valid Montgomery modulus
+// and R is a power of two greater than M and coprime to it. Otherwise, it
+// reports an error and returns false.
+func checkMontRadix(t test) (uint, bool) {
+	if !checkMontModulus(t) {
+		return 0, false
+	}
+	m, radix := t.Values["M"], t.Values["R"]
+	rBits := uint(radix.BitLen() - 1)
+	if radix.Sign() <= 0 || radix.Cmp(new(big.Int).Lsh(big.NewInt(1), rBits)) != 0 || radix.Cmp(m) <= 0 {
+		fmt.Fprintf(os.Stderr, "Line %d: R must be a power of two greater than M.\n", t.LineNumber)
+		return 0, false
+	}
+	if new(big.Int).GCD(nil, nil, radix, m).Cmp(big.NewInt(1)) != 0 {
+		fmt.Fprintf(os.Stderr, "Line %d: R and M are not coprime.\n", t.LineNumber)
+		return 0, false
+	}
+	return rBits, true
+}
+
+// karatsubaWordBits is the word size used to split operands in karatsubaMul.
+const karatsubaWordBits = 64
+
+// karatsubaMul returns a * b, computed by Karatsuba recursion on halves split
+// at a word boundary. Operands of at most |threshold| words are multiplied
+// directly. |threshold| must be at least one.
+func karatsubaMul(a, b *big.Int, threshold int) *big.Int {
+	aWords := (a.BitLen() + karatsubaWordBits - 1) / karatsubaWordBits
+	bWords := (b.BitLen() + karatsubaWordBits - 1) / karatsubaWordBits
+	n := aWords
+	if bWords > n {
+		n = bWords
+	}
+	if aWords <= threshold || bWords <= threshold {
+		return new(big.Int).Mul(a, b)
+	}
+
+	neg := a.Sign() != b.Sign()
+	a = new(big.Int).Abs(a)
+	b = new(big.Int).Abs(b)
+
+	// Split each operand as x = x1 * 2^shift + x0.
+	shift := uint(n/2) * karatsubaWordBits
+	mask := new(big.Int).Lsh(big.NewInt(1), shift)
+	mask.Sub(mask, big.NewInt(1))
+	a0, a1 := new(big.Int).And(a, mask), new(big.Int).Rsh(a, shift)
+	b0, b1 := new(big.Int).And(b, mask), new(big.Int).Rsh(b, shift)
+
+	z0 := karatsubaMul(a0, b0, threshold)
+	z2 := karatsubaMul(a1, b1, threshold)
+	z1 := karatsubaMul(new(big.Int).Add(a0, a1), new(big.Int).Add(b0, b1), threshold)
+	z1.Sub(z1, z0)
+	z1.Sub(z1, z2)
+
+	r := new(big.Int).Lsh(z2, 2*shift)
+	r.Add(r, z1.Lsh(z1, shift))
+	r.Add(r, z0)
+	if neg {
+		r.Neg(r)
+	}
+	return r
+}
+
+// fixedBaseExp computes g^e (mod m) for non-negative e using a table of
+// precomputed powers of g, as is done for a fixed base such as a group
+// generator. e is split into w-bit blocks and block i contributes
+// g^(digit * 2^(i*w)) from the table. It returns the running product after each
+// block, so the final element is the result.
+func fixedBaseExp(g, e, m *big.Int, w uint) []*big.Int {
+	blocks := (uint(e.BitLen()) + w - 1) / w
+
+	// table[i][j] = g^(j * 2^(i*w)) (mod m).
+	table := make([][]*big.Int, blocks)
+	base := new(big.Int).Mod(g, m)
+	for i := range table {
+		table[i] = make([]*big.Int, 1<<w)
+		table[i][0] = new(big.Int).Mod(big.NewInt(1), m)
+		for j := 1; j < len(table[i]); j++ {
+			table[i][j] = new(big.Int).Mul(table[i][j-1], base)
+			table[i][j].Mod(table[i][j], m)
+		}
+		// The next row's base is g^(2^((i+1)*w)) = table[i][2^w - 1] * base.
+		base = new(big.Int).Mul(table[i][len(table[i])-1], base)
+		base.Mod(base, m)
+	}
+
+	partials := make([]*big.Int, 0, blocks)
+	acc := new(big.Int).Mod(big.NewInt(1), m)
+	mask := big.NewInt(1<<w - 1)
+	for i := uint(0); i < blocks; i++ {
+		digit := new(big.Int).Rsh(e, i*w)
+		digit.And(digit, mask)
+		acc = new(big.Int).Mul(acc, table[i][digit.Int64()])
+		acc.Mod(acc, m)
+		partials = append(partials, acc)
+	}
+	if len(partials) == 0 {
+		partials = append(partials, acc)
+	}
+	return partials
+}
+
+// wnafDigits returns the width-w non-adjacent form of e, which must be
+// non-negative, least significant digit first. Each digit is either zero or odd
+// with absolute value less than 2^(w-1). The plain NAF is the case w = 2.
+func wnafDigits(e *big.Int, w uint) []int {
+	var digits []int
+	k := new(big.Int).Set(e)
+	mod := int64(1) << w
+	for k.Sign() > 0 {
+		var d int64
+		if k.Bit(0) == 1 {
+			d = new(big.Int).And(k, big.NewInt(mod-1)).Int64()
+			if d >= mod/2 {
+				d -= mod
+			}
+			k.Sub(k, big.NewInt(d))
+		}
+		digits = append(digits, int(d))
+		k.Rsh(k, 1)
+	}
+	return digits
+}
+
+// wnafExp computes a^e (mod m) from digits, the width-w non-adjacent form of
+// e, using precomputed odd powers of a and a^-1. a must be invertible modulo m.
+// It returns the accumulator after each digit, from the most significant digit
+// down, so the final element is the result.
+func wnafExp(a, m *big.Int, digits []int, w uint) []*big.Int {
+	// pos[i] = a^(2i+1) and neg[i] = a^-(2i+1) (mod m).
+	n := 1 << (w - 2)
+	pos := make([]*big.Int, n)
+	neg := make([]*big.Int, n)
+	pos[0] = new(big.Int).Mod(a, m)
+	neg[0] = new(big.Int).ModInverse(pos[0], m)
+	aSquared := new(big.Int).Mul(pos[0], pos[0])
+	aSquared.Mod(aSquared, m)
+	aInvSquared := new(big.Int).Mul(neg[0], neg[0])
+	aInvSquared.Mod(aInvSquared, m)
+	for i := 1; i < n; i++ {
+		pos[i] = new(big.Int).Mul(pos[i-1], aSquared)
+		pos[i].Mod(pos[i], m)
+		neg[i] = new(big.Int).Mul(neg[i-1], aInvSquared)
+		neg[i].Mod(neg[i], m)
+	}
+
+	partials := make([]*big.Int, 0, len(digits))
+	acc := new(big.Int).Mod(big.NewInt(1), m)
+	for i := len(digits) - 1; i >= 0; i-- {
+		acc = new(big.Int).Mul(acc, acc)
+		acc.Mod(acc, m)
+		if d := digits[i]; d > 0 {
+			acc.Mul(acc, pos[d/2])
+			acc.Mod(acc, m)
+		} else if d < 0 {
+			acc.Mul(acc, neg[-d/2])
+			acc.Mod(acc, m)
+		}
+		partials = append(partials, acc)
+	}
+	if len(partials) == 0 {
+		partials = append(partials, acc)
+	}
+	return partials
+}
+
+// checkWNAFExp checks A ^ E (mod M) for |test|, computed from the width-w
+// non-adjacent form of E, against |key|. It reports the first digit index at
+// which the running result diverges.
+func checkWNAFExp(t test, key string, w uint) {
+	a, e, m := t.Values["A"], t.Values["E"], t.Values["M"]
+	digits := wnafDigits(e, w)
+	partials := wnafExp(a, m, digits, w)
+
+	// prefix is the value of the digits processed so far.
+	prefix := new(big.Int)
+	for j, partial := range partials {
+		if j >= len(digits) {
+			break
+		}
+		i := len(digits) - 1 - j
+		prefix.Lsh(prefix, 1)
+		prefix.Add(prefix, big.NewInt(int64(digits[i])))
+		want := new(big.Int).Exp(a, prefix, m)
+		if partial.Cmp(want) != 0 {
+			fmt.Fprintf(os.Stderr, "Line %d: w-NAF exponentiation diverged at digit %d (%d).\n\tGot %s\n", t.LineNumber, i, digits[i], partial.Text(16))
+			break
+		}
+	}
+	checkResult(t, "w-NAF A ^ E (mod M)", key, partials[len(partials)-1])
+}
+
+// barrettMu returns floor(2^(2k) / m), the reciprocal used by Barrett
+// reduction with a k-bit modulus m.
+func barrettMu(m *big.Int, k uint) *big.Int {
+	mu := new(big.Int).Lsh(big.NewInt(1), 2*k)
+	return mu.Quo(mu, m)
+}
+
+// barrettReduce returns x (mod m) for x in [0, 2^(2k)), where m has bit length
+// k and mu = barrettMu(m, k).
+func barrettReduce(x, m, mu *big.Int, k uint) *big.Int {
+	q := new(big.Int).Rsh(x, k-1)
+	q.Mul(q, mu)
+	q.Rsh(q, k+1)
+	r := new(big.Int).Mul(q, m)
+	r.Sub(x, r)
+	for r.Cmp(m) >= 0 {
+		r.Sub(r, m)
+	}
+	return r
+}
+
+// gf2Mul returns the carry-less product of a and b, treated as polynomials over
+// GF(2). a and b must be non-negative.
+func gf2Mul(a, b *big.Int) *big.Int {
+	r := new(big.Int)
+	for i := 0; i < b.BitLen(); i++ {
+		if b.Bit(i) == 1 {
+			r.Xor(r, new(big.Int).Lsh(a, uint(i)))
+		}
+	}
+	return r
+}
+
+// gf2Mod returns a reduced modulo p, treated as polynomials over GF(2). a must
+// be non-negative and p must have positive degree.
+func gf2Mod(a, p *big.Int) *big.Int {
+	r := new(big.Int).Set(a)
+	for r.BitLen() >= p.BitLen() {
+		r.Xor(r, new(big.Int).Lsh(p, uint(r.BitLen()-p.BitLen())))
+	}
+	return r
+}
+
+// gf2Irreducible returns whether p, a polynomial over GF(2) of positive degree,
+// is irreducible. It uses Ben-Or's test: p of degree d is irreducible if and only
+// if gcd(x^(2^i) - x, p) = 1 for each i in [1, d/2].
+func gf2Irreducible(p *big.Int) bool {
+	x := big.NewInt(2)
+	t := new(big.Int).Set(x)
+	for i := 1; i <= (p.BitLen()-1)/2; i++ {
+		t = gf2Mod(gf2Mul(t, t), p)
+
+		a, b := new(big.Int).Set(p), new(big.Int).Xor(t, x)
+		for b.Sign() != 0 {
+			a, b = b, gf2Mod(a, b)
+		}
+		if a.Cmp(big.NewInt(1)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// windowExp computes a^e (mod m) for non-negative e with a w-bit window. If
+// sliding is true, it uses a sliding window over a table of the odd powers of
+// a. Otherwise, it uses a fixed window over a table of all powers of a below
+// 2^w. It returns the result and the number of table entries.
+func windowExp(a, e, m *big.Int, w uint, sliding bool) (*big.Int, int) {
+	base := new(big.Int).Mod(a, m)
+	var table []*big.Int
+	if sliding {
+		// table[i] = a^(2i+1).
+		table = make([]*big.Int, 1<<(w-1))
+		table[0] = base
+		baseSquared := new(big.Int).Mul(base, base)
+		baseSquared.Mod(baseSquared, m)
+		for i := 1; i < len(table); i++ {
+			table[i] = new(big.Int).Mul(table[i-1], baseSquared)
+			table[i].Mod(table[i], m)
+		}
+	} else {
+		// table[i] = a^i.
+		table = make([]*big.Int, 1<<w)
+		table[0] = new(big.Int).Mod(big.NewInt(1), m)
+		for i := 1; i < len(table); i++ {
+			table[i] = new(big.Int).Mul(table[i-1], base)
+			table[i].Mod(table[i], m)
+		}
+	}
+
+	acc := new(big.Int).Mod(big.NewInt(1), m)
+	square := func(n int) {
+		for ; n > 0; n-- {
+			acc.Mul(acc, acc)
+			acc.Mod(acc, m)
+		}
+	}
+
+	if sliding {
+		for i := e.BitLen() - 1; i >= 0; {
+			if e.Bit(i) == 0 {
+				square(1)
+				i--
+				continue
+			}
+			// Find the longest window e[i..l] of at most w bits ending in a
+			// one.
+			l := i - int(w) + 1
+			if l < 0 {
+				l = 0
+			}
+			for e.Bit(l) == 0 {
+				l++
+			}
+			var digit int
+			for j := i; j >= l; j-- {
+				digit = digit<<1 | int(e.Bit(j))
+			}
+			square(i - l + 1)
+			acc.Mul(acc, table[digit/2])
+			acc.Mod(acc, m)
+			i = l - 1
+		}
+	} else {
+		digits := (e.BitLen() + int(w) - 1) / int(w)
+		for i := digits - 1; i >= 0; i-- {
+			square(int(w))
+			var digit int
+			for j := int(w) - 1; j >= 0; j-- {
+				digit = digit<<1 | int(e.Bit(i*int(w)+j))
+			}
+			acc.Mul(acc, table[digit])
+			acc.Mod(acc, m)
+		}
+	}
+	return acc, len(table)
+}
+
+// euclidSteps returns the number of division steps the Euclidean algorithm
+// takes to compute gcd(|a|, |b|).
+func euclidSteps(a, b *big.Int) int {
+	x, y := new(big.Int).Abs(a), new(big.Int).Abs(b)
+	var steps int
+	for y.Sign() != 0 {
+		x.Mod(x, y)
+		x, y = y, x
+		steps++
+	}
+	return steps
+}
+
+// multiExp returns the product of bases[i]^exps[i] (mod m) for non-negative
+// exponents, computed by interleaved square-and-multiply (Shamir's trick) with
+// a single chain of squarings.
+func multiExp(bases, exps []*big.Int, m *big.Int) *big.Int {
+	var bits int
+	for _, e := range exps {
+		if e.BitLen() > bits {
+			bits = e.BitLen()
+		}
+	}
+
+	reduced := make([]*big.Int, len(bases))
+	for i, b := range bases {
+		reduced[i] = new(big.Int).Mod(b, m)
+	}
+
+	acc := new(big.Int).Mod(big.NewInt(1), m)
+	for bit := bits - 1; bit >= 0; bit-- {
+		acc.Mul(acc, acc)
+		acc.Mod(acc, m)
+		for i, e := range exps {
+			if e.Bit(bit) == 1 {
+				acc.Mul(acc, reduced[i])
+				acc.Mod(acc, m)
+			}
+		}
+	}
+	return acc
+}
+
+// crtCombine returns the unique x in [0, N), where N is the product of moduli,
+// such that x = residues[i] (mod moduli[i]) for each i. The moduli must be
+// pairwise coprime.
+func crtCombine(residues, moduli []*big.Int) *big.Int {
+	n := big.NewInt(1)
+	for _, m := range moduli {
+		n.Mul(n, m)
+	}
+
+	x := new(big.Int)
+	for i, m := range moduli {
+		ni := new(big.Int).Quo(n, m)
+		term := new(big.Int).ModInverse(ni, m)
+		term.Mul(term, ni)
+		term.Mul(term, residues[i])
+		x.Add(x, term)
+	}
+	return x.Mod(x, n)
+}
+
 func main() {
 	if len(os.Args) != 2 {
 		fmt.Fprintf(os.Stderr, "Usage: %s bn_tests.txt\n", os.Args[0])
@@ -252,6 +778,1475 @@ func main() {
 				r := new(big.Int).ModInverse(test.Values["A"], test.Values["M"])
 				checkResult(test, "A ^ -1 (mod M)", "ModInv", r)
 			}
+		case "ModSubNonNeg":
+			if checkKeys(test, "A", "B", "M", "ModSubNonNeg") {
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				// big.Int.Mod is Euclidean, so a negative A - B must still
+				// reduce into [0, M).
+				r := new(big.Int).Sub(test.Values["A"], test.Values["B"])
+				r = r.Mod(r, test.Values["M"])
+				if r.Sign() < 0 || r.Cmp(test.Values["M"]) >= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A - B (mod M) is not in [0, M).\n\tGot %s\n", test.LineNumber, r.Text(16))
+				}
+				checkResult(test, "A - B (mod M)", "ModSubNonNeg", r)
+			}
+		case "ModSquareHom":
+			if checkKeys(test, "A", "B", "M", "ModSquareHom") {
+				if !checkPositive(test, "M") {
+					break
+				}
+				m := test.Values["M"]
+
+				lhs := new(big.Int).Mul(test.Values["A"], test.Values["B"])
+				lhs.Mul(lhs, lhs)
+				lhs.Mod(lhs, m)
+				checkResult(test, "(A * B) ^ 2 (mod M)", "ModSquareHom", lhs)
+
+				aSquared := new(big.Int).Mul(test.Values["A"], test.Values["A"])
+				aSquared.Mod(aSquared, m)
+				bSquared := new(big.Int).Mul(test.Values["B"], test.Values["B"])
+				bSquared.Mod(bSquared, m)
+				rhs := new(big.Int).Mul(aSquared, bSquared)
+				rhs.Mod(rhs, m)
+				checkResult(test, "A ^ 2 * B ^ 2 (mod M)", "ModSquareHom", rhs)
+			}
+		case "BezoutBound":
+			if checkKeys(test, "A", "B", "X", "Y", "BezoutBound") {
+				a, b := test.Values["A"], test.Values["B"]
+				if !checkPositive(test, "A") || !checkPositive(test, "B") {
+					break
+				}
+
+				x, y := new(big.Int), new(big.Int)
+				g := new(big.Int).GCD(x, y, a, b)
+				checkResult(test, "gcd(A, B)", "BezoutBound", g)
+				checkResult(test, "Bezout coefficient of A", "X", x)
+				checkResult(test, "Bezout coefficient of B", "Y", y)
+
+				r := new(big.Int).Mul(a, test.Values["X"])
+				r.Add(r, new(big.Int).Mul(b, test.Values["Y"]))
+				checkResult(test, "A * X + B * Y", "BezoutBound", r)
+
+				// When A = B the coefficients are (0, 1), which does not
+				// satisfy the bound.
+				if a.Cmp(b) != 0 {
+					twoG := new(big.Int).Lsh(g, 1)
+					xBound := new(big.Int).Abs(test.Values["X"])
+					xBound.Mul(xBound, twoG)
+					if xBound.Cmp(b) > 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: |X| exceeds B / (2 * gcd(A, B)).\n", test.LineNumber)
+					}
+					yBound := new(big.Int).Abs(test.Values["Y"])
+					yBound.Mul(yBound, twoG)
+					if yBound.Cmp(a) > 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: |Y| exceeds A / (2 * gcd(A, B)).\n", test.LineNumber)
+					}
+				}
+			}
+		case "RadixEqual":
+			if checkKeys(test, "DecVal", "RadixEqual") {
+				if test.Values["RadixEqual"].Cmp(test.Values["DecVal"]) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: RadixEqual did not match DecVal.\n\tRadixEqual = %s\n\tDecVal = %s\n", test.LineNumber, test.Values["RadixEqual"].Text(10), test.Values["DecVal"].Text(10))
+				}
+			}
+		case "ModExp3CRT":
+			if checkKeys(test, "A", "E", "P", "Q", "R", "ModExpP", "ModExpQ", "ModExpR", "ModExp3CRT") {
+				moduli := []*big.Int{test.Values["P"], test.Values["Q"], test.Values["R"]}
+				names := []string{"P", "Q", "R"}
+
+				var tooSmall bool
+				for i, m := range moduli {
+					if m.Cmp(big.NewInt(1)) <= 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: %s must be greater than one.\n", test.LineNumber, names[i])
+						tooSmall = true
+					}
+				}
+				if tooSmall {
+					break
+				}
+
+				var notCoprime bool
+				for i := range moduli {
+					for j := i + 1; j < len(moduli); j++ {
+						if new(big.Int).GCD(nil, nil, moduli[i], moduli[j]).Cmp(big.NewInt(1)) != 0 {
+							fmt.Fprintf(os.Stderr, "Line %d: %s and %s are not coprime.\n", test.LineNumber, names[i], names[j])
+							notCoprime = true
+						}
+					}
+				}
+				if notCoprime {
+					break
+				}
+
+				n := new(big.Int).Mul(moduli[0], moduli[1])
+				n.Mul(n, moduli[2])
+				r := new(big.Int).Exp(test.Values["A"], test.Values["E"], n)
+				checkResult(test, "A ^ E (mod P * Q * R)", "ModExp3CRT", r)
+
+				residues := make([]*big.Int, len(moduli))
+				for i, m := range moduli {
+					key := "ModExp" + names[i]
+					residues[i] = test.Values[key]
+
+					r = new(big.Int).Exp(test.Values["A"], test.Values["E"], m)
+					checkResult(test, "A ^ E (mod "+names[i]+")", key, r)
+
+					r = new(big.Int).Mod(test.Values["ModExp3CRT"], m)
+					checkResult(test, "ModExp3CRT (mod "+names[i]+")", key, r)
+				}
+
+				r = crtCombine(residues, moduli)
+				checkResult(test, "CRT(ModExpP, ModExpQ, ModExpR)", "ModExp3CRT", r)
+			}
+		case "MontR2":
+			if checkKeys(test, "M", "K", "WordBits", "MontR2") {
+				if !checkMontModulus(test) {
+					break
+				}
+				m := test.Values["M"]
+				wordBits, ok := checkWordBits(test)
+				if !ok {
+					break
+				}
+				k, ok := checkSmallValue(test, "K", 1<<16)
+				if !ok {
+					break
+				}
+				rBits := k * wordBits
+				if uint(m.BitLen()) > rBits {
+					fmt.Fprintf(os.Stderr, "Line %d: M does not fit in K words.\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Lsh(big.NewInt(1), 2*rBits)
+				r.Mod(r, m)
+				checkResult(test, "R ^ 2 (mod M)", "MontR2", r)
+
+				r2 := test.Values["MontR2"]
+				if r2.Sign() < 0 || r2.Cmp(m) >= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: MontR2 is not in [0, M).\n", test.LineNumber)
+					break
+				}
+
+				// Converting x to Montgomery form is REDC(x * R^2) = x * R
+				// (mod M).
+				for _, x := range []*big.Int{big.NewInt(1), new(big.Int).Sub(m, big.NewInt(1))} {
+					want := new(big.Int).Lsh(x, rBits)
+					want.Mod(want, m)
+					got := montgomeryReduce(new(big.Int).Mul(x, r2), m, rBits)
+					if got.Cmp(want) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: REDC(%s * MontR2) did not match %s * R (mod M).\n\tGot %s\n", test.LineNumber, x.Text(16), x.Text(16), got.Text(16))
+					}
+				}
+			}
+		case "LShiftWord":
+			if checkKeys(test, "A", "K", "WordBits", "LShiftWord") {
+				wordBits, ok := checkWordBits(test)
+				if !ok {
+					break
+				}
+				// Bounding K keeps K * WordBits well within a uint.
+				k, ok := checkSmallValue(test, "K", 1<<16)
+				if !ok {
+					break
+				}
+
+				r := new(big.Int).Lsh(test.Values["A"], k*wordBits)
+				checkResult(test, "A << (K * WordBits)", "LShiftWord", r)
+
+				r = new(big.Int).Lsh(big.NewInt(1), wordBits)
+				r.Exp(r, test.Values["K"], nil)
+				r.Mul(r, test.Values["A"])
+				checkResult(test, "A * (2 ^ WordBits) ^ K", "LShiftWord", r)
+			}
+		case "ModInvExists":
+			if checkKeys(test, "A", "M", "ModInvExists") {
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				gcd := new(big.Int).GCD(nil, nil, test.Values["A"], test.Values["M"])
+				coprime := gcd.Cmp(big.NewInt(1)) == 0
+				hasInverse := new(big.Int).ModInverse(test.Values["A"], test.Values["M"]) != nil
+				if coprime != hasInverse {
+					fmt.Fprintf(os.Stderr, "Line %d: gcd(A, M) = %s, but ModInverse returned an inverse: %t.\n", test.LineNumber, gcd.Text(16), hasInverse)
+				}
+
+				r := new(big.Int)
+				if coprime {
+					r.SetInt64(1)
+				}
+				checkResult(test, "gcd(A, M) == 1", "ModInvExists", r)
+			}
+		case "Karatsuba":
+			if checkKeys(test, "A", "B", "Threshold", "Karatsuba") {
+				threshold, ok := checkSmallValue(test, "Threshold", 1<<16)
+				if !ok {
+					break
+				}
+				if threshold == 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Threshold must be at least one.\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Mul(test.Values["A"], test.Values["B"])
+				checkResult(test, "A * B", "Karatsuba", r)
+
+				k := karatsubaMul(test.Values["A"], test.Values["B"], int(threshold))
+				checkResult(test, "Karatsuba(A, B)", "Karatsuba", k)
+				if k.Cmp(r) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Karatsuba(A, B) did not match big.Int.Mul.\n", test.LineNumber)
+				}
+			}
+		case "Normalize":
+			if checkKeys(test, "A", "M", "Normalize") {
+				m, n := test.Values["M"], test.Values["Normalize"]
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				if n.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Normalize is negative.\n", test.LineNumber)
+				}
+				if n.Cmp(m) >= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Normalize is not less than M.\n", test.LineNumber)
+				}
+				diff := new(big.Int).Sub(test.Values["A"], n)
+				if diff.Mod(diff, m).Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Normalize is not congruent to A (mod M).\n", test.LineNumber)
+				}
+
+				r := new(big.Int).Mod(test.Values["A"], m)
+				checkResult(test, "A (mod M)", "Normalize", r)
+			}
+		case "BitSlice":
+			if checkKeys(test, "A", "Start", "Length", "BitSlice") {
+				if test.Values["A"].Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A must be non-negative.\n", test.LineNumber)
+					break
+				}
+				start, ok := checkSmallValue(test, "Start", 1<<20)
+				if !ok {
+					break
+				}
+				length, ok := checkSmallValue(test, "Length", 1<<20)
+				if !ok {
+					break
+				}
+
+				mask := new(big.Int).Lsh(big.NewInt(1), length)
+				mask.Sub(mask, big.NewInt(1))
+				r := new(big.Int).Rsh(test.Values["A"], start)
+				r.And(r, mask)
+				checkResult(test, "(A >> Start) & (2 ^ Length - 1)", "BitSlice", r)
+			}
+		case "CarryChain":
+			if checkKeys(test, "A", "B", "Carry", "CarryChain") {
+				a, b := test.Values["A"], test.Values["B"]
+				if a.Sign() < 0 || b.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A and B must be non-negative.\n", test.LineNumber)
+					break
+				}
+				carry, ok := checkSmallValue(test, "Carry", 1)
+				if !ok {
+					break
+				}
+
+				r := new(big.Int).Add(a, b)
+				checkResult(test, "A + B", "CarryChain", r)
+
+				bits := a.BitLen()
+				if b.BitLen() > bits {
+					bits = b.BitLen()
+				}
+				if sumBits := test.Values["CarryChain"].BitLen(); sumBits != bits+int(carry) {
+					fmt.Fprintf(os.Stderr, "Line %d: CarryChain has bit length %d, but expected %d with Carry = %d.\n", test.LineNumber, sumBits, bits+int(carry), carry)
+				}
+			}
+		case "BalancedMod":
+			if checkKeys(test, "A", "M", "BalancedMod") {
+				m, b := test.Values["M"], test.Values["BalancedMod"]
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				halfM := new(big.Int).Rsh(m, 1)
+				r := new(big.Int).Mod(test.Values["A"], m)
+				if r.Cmp(halfM) > 0 {
+					r.Sub(r, m)
+				}
+				checkResult(test, "balanced A (mod M)", "BalancedMod", r)
+
+				diff := new(big.Int).Sub(test.Values["A"], b)
+				if diff.Mod(diff, m).Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: BalancedMod is not congruent to A (mod M).\n", test.LineNumber)
+				}
+				// Compare 2 * BalancedMod against M to avoid rounding M / 2.
+				twoB := new(big.Int).Lsh(b, 1)
+				if twoB.Cmp(new(big.Int).Neg(m)) <= 0 || twoB.Cmp(m) > 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: BalancedMod is not in (-M / 2, M / 2].\n", test.LineNumber)
+				}
+			}
+		case "ResidueSet":
+			if checkKeys(test, "P", "ResidueSet") {
+				p := test.Values["P"]
+				if p.Cmp(big.NewInt(3)) < 0 || p.Cmp(big.NewInt(1<<16)) > 0 || !p.ProbablyPrime(20) {
+					fmt.Fprintf(os.Stderr, "Line %d: P must be an odd prime at most 2^16.\n", test.LineNumber)
+					break
+				}
+
+				residues := make(map[int64]bool)
+				pMinus1Over2 := (p.Int64() - 1) / 2
+				for x := int64(1); x <= pMinus1Over2; x++ {
+					residues[x*x%p.Int64()] = true
+				}
+
+				list := test.Lists["ResidueSet"]
+				listed := make(map[int64]bool)
+				for i, r := range list {
+					if i > 0 && r.Cmp(list[i-1]) <= 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: ResidueSet is not in increasing order at %s.\n", test.LineNumber, r.Text(16))
+					}
+					if !r.IsInt64() || !residues[r.Int64()] {
+						fmt.Fprintf(os.Stderr, "Line %d: ResidueSet has extra member %s.\n", test.LineNumber, r.Text(16))
+					} else {
+						listed[r.Int64()] = true
+					}
+					if r.Sign() > 0 && r.Cmp(p) < 0 && big.Jacobi(r, p) != 1 {
+						fmt.Fprintf(os.Stderr, "Line %d: Legendre symbol of ResidueSet member %s is not 1.\n", test.LineNumber, r.Text(16))
+					}
+				}
+				for x := int64(1); x < p.Int64(); x++ {
+					if residues[x] && !listed[x] {
+						fmt.Fprintf(os.Stderr, "Line %d: ResidueSet is missing member %x.\n", test.LineNumber, x)
+					}
+				}
+			}
+		case "ModExpBlinded":
+			if checkKeys(test, "A", "E", "D", "N", "Blind", "ModExpBlinded") {
+				if !checkPositive(test, "N") {
+					break
+				}
+				n, blind := test.Values["N"], test.Values["Blind"]
+				blindInv := new(big.Int).ModInverse(blind, n)
+				if blindInv == nil {
+					fmt.Fprintf(os.Stderr, "Line %d: Blind is not coprime to N.\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Exp(test.Values["A"], test.Values["D"], n)
+				checkResult(test, "A ^ D (mod N)", "ModExpBlinded", r)
+
+				blinded := new(big.Int).Exp(blind, test.Values["E"], n)
+				blinded.Mul(blinded, test.Values["A"])
+				blinded.Mod(blinded, n)
+				r = new(big.Int).Exp(blinded, test.Values["D"], n)
+				r.Mul(r, blindInv)
+				r.Mod(r, n)
+				checkResult(test, "(A * Blind ^ E) ^ D * Blind ^ -1 (mod N)", "ModExpBlinded", r)
+			}
+		case "ShiftBoundary":
+			if checkKeys(test, "A", "N", "LShift", "ShiftBoundary") {
+				a := test.Values["A"]
+				if a.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A must be non-negative.\n", test.LineNumber)
+					break
+				}
+				n, ok := checkSmallValue(test, "N", uint64(a.BitLen())+1)
+				if !ok {
+					break
+				}
+				if n+1 < uint(a.BitLen()) {
+					fmt.Fprintf(os.Stderr, "Line %d: N must be within one of the bit length of A.\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Rsh(a, n)
+				checkResult(test, fmt.Sprintf("A >> %d", n), "ShiftBoundary", r)
+				// Only N = BitLen(A) - 1 leaves the top bit of a non-zero A.
+				wantR := int64(0)
+				if a.Sign() != 0 && n+1 == uint(a.BitLen()) {
+					wantR = 1
+				}
+				if rs := test.Values["ShiftBoundary"]; rs.Cmp(big.NewInt(wantR)) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A >> %d should be %d.\n\tGot %s\n", test.LineNumber, n, wantR, rs.Text(16))
+				}
+
+				r = new(big.Int).Lsh(a, n)
+				checkResult(test, fmt.Sprintf("A << %d", n), "LShift", r)
+				wantBits := 0
+				if a.Sign() != 0 {
+					wantBits = a.BitLen() + int(n)
+				}
+				if bits := test.Values["LShift"].BitLen(); bits != wantBits {
+					fmt.Fprintf(os.Stderr, "Line %d: A << %d has bit length %d, but expected %d.\n", test.LineNumber, n, bits, wantBits)
+				}
+			}
+		case "FixedBaseModExp":
+			keys := []string{"G", "E", "M", "FixedBaseModExp"}
+			_, hasW := test.Values["W"]
+			if hasW {
+				keys = append(keys, "W")
+			}
+			if checkKeys(test, keys...) {
+				e, m := test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+				w := uint(4)
+				if hasW {
+					var ok bool
+					if w, ok = checkSmallValue(test, "W", 8); !ok {
+						break
+					}
+					if w == 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: W must be at least one.\n", test.LineNumber)
+						break
+					}
+				}
+
+				r := new(big.Int).Exp(test.Values["G"], e, m)
+				checkResult(test, "G ^ E (mod M)", "FixedBaseModExp", r)
+
+				partials := fixedBaseExp(test.Values["G"], e, m, w)
+				for i, partial := range partials {
+					mask := new(big.Int).Lsh(big.NewInt(1), uint(i+1)*w)
+					mask.Sub(mask, big.NewInt(1))
+					want := new(big.Int).Exp(test.Values["G"], mask.And(mask, e), m)
+					if partial.Cmp(want) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: fixed-base product diverged at block %d.\n\tGot %s\n", test.LineNumber, i, partial.Text(16))
+						break
+					}
+				}
+				checkResult(test, "fixed-base G ^ E (mod M)", "FixedBaseModExp", partials[len(partials)-1])
+			}
+		case "ModInverseAdditive":
+			if checkKeys(test, "A", "M", "ModInverseAdditive") {
+				m := test.Values["M"]
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				r := new(big.Int).Add(test.Values["A"], test.Values["ModInverseAdditive"])
+				r.Mod(r, m)
+				if r.Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A + ModInverseAdditive (mod M) is not zero.\n\tGot %s\n", test.LineNumber, r.Text(16))
+				}
+			}
+		case "ProductBitLen":
+			if checkKeys(test, "A", "B", "ProductBitLen") {
+				a, b := test.Values["A"], test.Values["B"]
+				r := new(big.Int).Mul(a, b)
+				checkResult(test, "A * B", "ProductBitLen", r)
+
+				bits := test.Values["ProductBitLen"].BitLen()
+				if a.Sign() == 0 || b.Sign() == 0 {
+					if bits != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: ProductBitLen has bit length %d, but an operand is zero.\n", test.LineNumber, bits)
+					}
+				} else if sum := a.BitLen() + b.BitLen(); bits != sum && bits != sum-1 {
+					fmt.Fprintf(os.Stderr, "Line %d: ProductBitLen has bit length %d, but expected %d or %d.\n", test.LineNumber, bits, sum, sum-1)
+				}
+			}
+		case "ModExpDecompose":
+			if checkKeys(test, "A", "E", "M", "ModExpDecompose") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "ModExpDecompose", r)
+
+				// power is A ^ (2 ^ i) (mod M) and acc is the product of the
+				// contributions of bits 0 through i.
+				power := new(big.Int).Mod(a, m)
+				acc := new(big.Int).Mod(big.NewInt(1), m)
+				low := new(big.Int)
+				for i := 0; i < e.BitLen(); i++ {
+					if e.Bit(i) == 1 {
+						acc.Mul(acc, power)
+						acc.Mod(acc, m)
+						low.SetBit(low, i, 1)
+
+						want := new(big.Int).Exp(a, low, m)
+						if acc.Cmp(want) != 0 {
+							fmt.Fprintf(os.Stderr, "Line %d: partial product diverged at bit %d.\n\tGot %s\n", test.LineNumber, i, acc.Text(16))
+							break
+						}
+					}
+					power.Mul(power, power)
+					power.Mod(power, m)
+				}
+				checkResult(test, "product of A ^ (2 ^ i) (mod M)", "ModExpDecompose", acc)
+			}
+		case "FitsInWords":
+			if checkKeys(test, "A", "NumWords", "WordBits", "FitsInWords") {
+				wordBits, ok := checkWordBits(test)
+				if !ok {
+					break
+				}
+				numWords, ok := checkSmallValue(test, "NumWords", 1<<16)
+				if !ok {
+					break
+				}
+
+				r := new(big.Int)
+				if uint(test.Values["A"].BitLen()) <= numWords*wordBits {
+					r.SetInt64(1)
+				}
+				checkResult(test, "BitLen(A) <= NumWords * WordBits", "FitsInWords", r)
+			}
+		case "ModExpSquareTable":
+			if checkKeys(test, "A", "E", "M", "ModExpSquareTable") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "ModExpSquareTable", r)
+
+				// table[i] = A ^ (2 ^ i) (mod M).
+				table := make([]*big.Int, e.BitLen()+1)
+				table[0] = new(big.Int).Mod(a, m)
+				for i := 1; i < len(table); i++ {
+					table[i] = new(big.Int).Mul(table[i-1], table[i-1])
+					table[i].Mod(table[i], m)
+				}
+
+				diverged := false
+				for i, entry := range table {
+					want := new(big.Int).Exp(a, new(big.Int).Lsh(big.NewInt(1), uint(i)), m)
+					if entry.Cmp(want) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: squaring table diverged at index %d.\n\tGot %s\n", test.LineNumber, i, entry.Text(16))
+						diverged = true
+						break
+					}
+				}
+				if diverged {
+					break
+				}
+
+				r = new(big.Int).Mod(big.NewInt(1), m)
+				for i := 0; i < e.BitLen(); i++ {
+					if e.Bit(i) == 1 {
+						r.Mul(r, table[i])
+						r.Mod(r, m)
+					}
+				}
+				checkResult(test, "squaring table A ^ E (mod M)", "ModExpSquareTable", r)
+			}
+		case "ReduceFraction":
+			if checkKeys(test, "Numerator", "Denominator", "ReducedDenominator", "ReduceFraction") {
+				num, den := test.Values["Numerator"], test.Values["Denominator"]
+				if den.Sign() == 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Denominator must be non-zero.\n", test.LineNumber)
+					break
+				}
+
+				g := new(big.Int).GCD(nil, nil, new(big.Int).Abs(num), new(big.Int).Abs(den))
+				rNum := new(big.Int).Quo(num, g)
+				rDen := new(big.Int).Quo(den, g)
+				if rDen.Sign() < 0 {
+					rNum.Neg(rNum)
+					rDen.Neg(rDen)
+				}
+				checkResult(test, "Numerator / g", "ReduceFraction", rNum)
+				checkResult(test, "Denominator / g", "ReducedDenominator", rDen)
+
+				wantNum, wantDen := test.Values["ReduceFraction"], test.Values["ReducedDenominator"]
+				if wantDen.Sign() <= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: ReducedDenominator is not positive.\n", test.LineNumber)
+				}
+				if new(big.Int).GCD(nil, nil, new(big.Int).Abs(wantNum), new(big.Int).Abs(wantDen)).Cmp(big.NewInt(1)) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: ReduceFraction / ReducedDenominator is not in lowest terms.\n", test.LineNumber)
+				}
+				lhs := new(big.Int).Mul(wantNum, den)
+				if lhs.Cmp(new(big.Int).Mul(num, wantDen)) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: ReduceFraction / ReducedDenominator does not equal Numerator / Denominator.\n", test.LineNumber)
+				}
+			}
+		case "CTEqual":
+			if checkKeys(test, "A", "B", "CTEqual") {
+				a, b := test.Values["A"], test.Values["B"]
+
+				r := new(big.Int)
+				if a.Cmp(b) == 0 {
+					r.SetInt64(1)
+				}
+				checkResult(test, "A == B", "CTEqual", r)
+
+				width := len(a.Bytes())
+				if len(b.Bytes()) > width {
+					width = len(b.Bytes())
+				}
+				aBytes := a.FillBytes(make([]byte, width))
+				bBytes := b.FillBytes(make([]byte, width))
+				ctEqual := subtle.ConstantTimeCompare(aBytes, bBytes) & subtle.ConstantTimeEq(int32(a.Sign()), int32(b.Sign()))
+				if ctEqual != int(r.Int64()) {
+					fmt.Fprintf(os.Stderr, "Line %d: constant-time comparison returned %d, but A.Cmp(B) returned %d.\n", test.LineNumber, ctEqual, a.Cmp(b))
+				}
+			}
+		case "ModIdempotent":
+			if checkKeys(test, "A", "M", "ModIdempotent") {
+				m := test.Values["M"]
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				once := new(big.Int).Mod(test.Values["A"], m)
+				checkResult(test, "A (mod M)", "ModIdempotent", once)
+
+				twice := new(big.Int).Mod(once, m)
+				checkResult(test, "(A (mod M)) (mod M)", "ModIdempotent", twice)
+
+				r := new(big.Int).Mod(test.Values["ModIdempotent"], m)
+				checkResult(test, "ModIdempotent (mod M)", "ModIdempotent", r)
+			}
+		case "SignExtend":
+			if checkKeys(test, "A", "FromWidth", "ToWidth", "SignExtend") {
+				a := test.Values["A"]
+				from, ok := checkSmallValue(test, "FromWidth", 1<<16)
+				if !ok {
+					break
+				}
+				to, ok := checkSmallValue(test, "ToWidth", 1<<16)
+				if !ok {
+					break
+				}
+				if from == 0 || to < from {
+					fmt.Fprintf(os.Stderr, "Line %d: FromWidth must be positive and at most ToWidth.\n", test.LineNumber)
+					break
+				}
+				if a.Sign() < 0 || uint(a.BitLen()) > from {
+					fmt.Fprintf(os.Stderr, "Line %d: A must be in [0, 2 ^ FromWidth).\n", test.LineNumber)
+					break
+				}
+
+				signed := new(big.Int).Set(a)
+				if a.Bit(int(from-1)) == 1 {
+					signed.Sub(signed, new(big.Int).Lsh(big.NewInt(1), from))
+				}
+				toMod := new(big.Int).Lsh(big.NewInt(1), to)
+				r := new(big.Int).Mod(signed, toMod)
+				checkResult(test, "sign-extended A", "SignExtend", r)
+
+				// Interpreting SignExtend as a ToWidth-bit signed value must
+				// recover the signed value of A.
+				ext := test.Values["SignExtend"]
+				roundTrip := new(big.Int).Set(ext)
+				if ext.Bit(int(to-1)) == 1 {
+					roundTrip.Sub(roundTrip, toMod)
+				}
+				if ext.Sign() < 0 || ext.Cmp(toMod) >= 0 || roundTrip.Cmp(signed) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: SignExtend does not round-trip to A as a signed value.\n", test.LineNumber)
+				}
+			}
+		case "PowTwoExp":
+			if checkKeys(test, "A", "K", "M", "PowTwoExp") {
+				a, m := test.Values["A"], test.Values["M"]
+				k, ok := checkSmallValue(test, "K", 4096)
+				if !ok {
+					break
+				}
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				r := new(big.Int).Exp(a, new(big.Int).Lsh(big.NewInt(1), k), m)
+				checkResult(test, "A ^ (2 ^ K) (mod M)", "PowTwoExp", r)
+
+				r = new(big.Int).Mod(a, m)
+				for i := uint(1); i <= k; i++ {
+					r.Mul(r, r)
+					r.Mod(r, m)
+					want := new(big.Int).Exp(a, new(big.Int).Lsh(big.NewInt(1), i), m)
+					if r.Cmp(want) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: repeated squaring diverged at iteration %d.\n\tGot %s\n", test.LineNumber, i, r.Text(16))
+						break
+					}
+				}
+				checkResult(test, "A squared K times (mod M)", "PowTwoExp", r)
+			}
+		case "GeneratorCheck":
+			if checkKeys(test, "G", "P", "GeneratorCheck") {
+				p := test.Values["P"]
+				if p.Cmp(big.NewInt(2)) < 0 || p.Cmp(big.NewInt(1<<16)) > 0 || !p.ProbablyPrime(20) {
+					fmt.Fprintf(os.Stderr, "Line %d: P must be a prime at most 2^16.\n", test.LineNumber)
+					break
+				}
+
+				// Every power of zero is zero, which is not in 1, ..., P - 1.
+				// This matters for P = 2, where there is only one power and
+				// so nothing can repeat.
+				g := new(big.Int).Mod(test.Values["G"], p)
+				x := big.NewInt(1)
+				seen := make(map[int64]bool)
+				var repeated *big.Int
+				for i := int64(1); i < p.Int64() && g.Sign() != 0; i++ {
+					x.Mul(x, g)
+					x.Mod(x, p)
+					if seen[x.Int64()] {
+						repeated = x
+						break
+					}
+					seen[x.Int64()] = true
+				}
+
+				r := new(big.Int)
+				if repeated == nil && g.Sign() != 0 {
+					r.SetInt64(1)
+				}
+				checkResult(test, "G is a primitive root (mod P)", "GeneratorCheck", r)
+				if repeated != nil && test.Values["GeneratorCheck"].Cmp(r) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: powers of G repeated %s.\n", test.LineNumber, repeated.Text(16))
+				}
+			}
+		case "RemainderSign":
+			if checkKeys(test, "A", "B", "RemTrunc", "RemFloor", "RemainderSign") {
+				a, b := test.Values["A"], test.Values["B"]
+				if b.Sign() == 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: B must be non-zero.\n", test.LineNumber)
+					break
+				}
+
+				_, trunc := new(big.Int).QuoRem(a, b, new(big.Int))
+				checkResult(test, "A % B (truncated)", "RemTrunc", trunc)
+
+				// big.Int.DivMod is Euclidean rather than floored, so derive
+				// the floored remainder from the truncated one.
+				floor := new(big.Int).Set(trunc)
+				if trunc.Sign() != 0 && trunc.Sign() != b.Sign() {
+					floor.Add(floor, b)
+				}
+				checkResult(test, "A % B (floored)", "RemFloor", floor)
+
+				r := new(big.Int)
+				if trunc.Cmp(floor) != 0 {
+					r.SetInt64(1)
+				}
+				checkResult(test, "RemTrunc != RemFloor", "RemainderSign", r)
+
+				oppositeSigns := a.Sign()*b.Sign() < 0
+				diff := new(big.Int).Sub(test.Values["RemFloor"], test.Values["RemTrunc"])
+				if diff.Sign() != 0 && (!oppositeSigns || diff.Cmp(b) != 0) {
+					fmt.Fprintf(os.Stderr, "Line %d: RemFloor - RemTrunc should be zero or B for opposite signs.\n\tGot %s\n", test.LineNumber, diff.Text(16))
+				}
+			}
+		case "Identities":
+			if checkKeys(test, "A", "M", "Identities") {
+				a, m := test.Values["A"], test.Values["M"]
+				if !checkPositive(test, "M") {
+					break
+				}
+				one, zero := big.NewInt(1), big.NewInt(0)
+
+				aTimesOne := new(big.Int).Mul(a, one)
+				if aTimesOne.Cmp(a) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A * 1 did not match A.\n\tGot %s\n", test.LineNumber, aTimesOne.Text(16))
+				}
+				aTimesZero := new(big.Int).Mul(a, zero)
+				if aTimesZero.Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A * 0 is not zero.\n\tGot %s\n", test.LineNumber, aTimesZero.Text(16))
+				}
+
+				r := new(big.Int).Mod(a, m)
+				checkResult(test, "A (mod M)", "Identities", r)
+				checkResult(test, "A * 1 (mod M)", "Identities", aTimesOne.Mod(aTimesOne, m))
+				if aTimesZero.Mod(aTimesZero, m).Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A * 0 (mod M) is not zero.\n\tGot %s\n", test.LineNumber, aTimesZero.Text(16))
+				}
+			}
+		case "RootCount":
+			if checkKeys(test, "A", "P", "RootCount") {
+				p := test.Values["P"]
+				if p.Cmp(big.NewInt(3)) < 0 || !p.ProbablyPrime(20) {
+					fmt.Fprintf(os.Stderr, "Line %d: P must be an odd prime.\n", test.LineNumber)
+					break
+				}
+
+				a := new(big.Int).Mod(test.Values["A"], p)
+				predicted := int64(0)
+				switch big.Jacobi(a, p) {
+				case 0:
+					predicted = 1
+				case 1:
+					predicted = 2
+				}
+				checkResult(test, "count predicted by Legendre(A, P)", "RootCount", big.NewInt(predicted))
+
+				roots := make(map[string]bool)
+				if root := new(big.Int).ModSqrt(a, p); root != nil {
+					for _, x := range []*big.Int{root, new(big.Int).Mod(new(big.Int).Neg(root), p)} {
+						square := new(big.Int).Mul(x, x)
+						if square.Mod(square, p).Cmp(a) != 0 {
+							fmt.Fprintf(os.Stderr, "Line %d: %s is not a square root of A (mod P).\n", test.LineNumber, x.Text(16))
+							continue
+						}
+						roots[x.String()] = true
+					}
+				}
+				if int64(len(roots)) != predicted {
+					fmt.Fprintf(os.Stderr, "Line %d: Legendre(A, P) predicts %d roots, but found %d.\n", test.LineNumber, predicted, len(roots))
+				}
+			}
+		case "NAFExp":
+			if checkKeys(test, "A", "E", "M", "NAFExp") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+				if new(big.Int).GCD(nil, nil, a, m).Cmp(big.NewInt(1)) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A is not invertible (mod M).\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "NAFExp", r)
+				checkWNAFExp(test, "NAFExp", 2)
+			}
+		case "SubWidth":
+			if checkKeys(test, "A", "B", "Width", "Borrow", "SubWidth") {
+				a, b := test.Values["A"], test.Values["B"]
+				width, ok := checkSmallValue(test, "Width", 1<<16)
+				if !ok {
+					break
+				}
+				if a.Sign() < 0 || b.Sign() < 0 || uint(a.BitLen()) > width || uint(b.BitLen()) > width {
+					fmt.Fprintf(os.Stderr, "Line %d: A and B must be in [0, 2 ^ Width).\n", test.LineNumber)
+					break
+				}
+
+				mask := new(big.Int).Lsh(big.NewInt(1), width)
+				mask.Sub(mask, big.NewInt(1))
+				r := new(big.Int).Sub(a, b)
+				r.And(r, mask)
+				checkResult(test, "A - B (mod 2 ^ Width)", "SubWidth", r)
+
+				borrow := new(big.Int)
+				if a.Cmp(b) < 0 {
+					borrow.SetInt64(1)
+				}
+				checkResult(test, "A < B", "Borrow", borrow)
+			}
+		case "ModExpReduceAgnostic":
+			if checkKeys(test, "A", "E", "M", "ModExpReduceAgnostic") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "ModExpReduceAgnostic", r)
+
+				k := uint(m.BitLen())
+				mu := barrettMu(m, k)
+				base := new(big.Int).Mod(a, m)
+				acc := new(big.Int).Mod(big.NewInt(1), m)
+				for i := e.BitLen() - 1; i >= 0; i-- {
+					acc = barrettReduce(new(big.Int).Mul(acc, acc), m, mu, k)
+					if e.Bit(i) == 1 {
+						acc = barrettReduce(new(big.Int).Mul(acc, base), m, mu, k)
+					}
+					want := new(big.Int).Exp(a, new(big.Int).Rsh(e, uint(i)), m)
+					if acc.Cmp(want) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: Barrett square-and-multiply diverged at bit %d.\n\tGot %s\n", test.LineNumber, i, acc.Text(16))
+						break
+					}
+				}
+				checkResult(test, "Barrett A ^ E (mod M)", "ModExpReduceAgnostic", acc)
+			}
+		case "NotWidth":
+			if checkKeys(test, "A", "Width", "NotWidth") {
+				a := test.Values["A"]
+				width, ok := checkSmallValue(test, "Width", 1<<16)
+				if !ok {
+					break
+				}
+				if a.Sign() < 0 || uint(a.BitLen()) > width {
+					fmt.Fprintf(os.Stderr, "Line %d: A must be in [0, 2 ^ Width).\n", test.LineNumber)
+					break
+				}
+
+				mask := new(big.Int).Lsh(big.NewInt(1), width)
+				mask.Sub(mask, big.NewInt(1))
+				r := new(big.Int).Xor(mask, a)
+				checkResult(test, "NOT A (Width bits)", "NotWidth", r)
+
+				complement := test.Values["NotWidth"]
+				if complement.Sign() < 0 || uint(complement.BitLen()) > width {
+					fmt.Fprintf(os.Stderr, "Line %d: NotWidth does not fit in Width bits.\n", test.LineNumber)
+				}
+				r = new(big.Int).Xor(mask, complement)
+				checkResult(test, "NOT NotWidth (Width bits)", "A", r)
+			}
+		case "ModExpNested":
+			if checkKeys(test, "A", "E1", "E2", "M", "ModExpNested") {
+				a, e1, e2, m := test.Values["A"], test.Values["E1"], test.Values["E2"], test.Values["M"]
+				if e1.Sign() < 0 || e2.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: E1 and E2 must be non-negative.\n", test.LineNumber)
+					break
+				}
+				e := new(big.Int).Mul(e1, e2)
+				if e.BitLen() > 8192 {
+					fmt.Fprintf(os.Stderr, "Line %d: E1 * E2 must be at most 8192 bits.\n", test.LineNumber)
+					break
+				}
+				if !checkPositive(test, "M") {
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ (E1 * E2) (mod M)", "ModExpNested", r)
+
+				r = new(big.Int).Exp(a, e1, m)
+				r.Exp(r, e2, m)
+				checkResult(test, "(A ^ E1) ^ E2 (mod M)", "ModExpNested", r)
+			}
+		case "BarrettMu":
+			if checkKeys(test, "M", "K", "BarrettMu") {
+				m := test.Values["M"]
+				if !checkPositive(test, "M") {
+					break
+				}
+				k, ok := checkSmallValue(test, "K", 1<<16)
+				if !ok {
+					break
+				}
+				if k < uint(m.BitLen()) {
+					fmt.Fprintf(os.Stderr, "Line %d: K must be at least the bit length of M.\n", test.LineNumber)
+					break
+				}
+
+				r := barrettMu(m, k)
+				checkResult(test, "floor(2 ^ (2 * K) / M)", "BarrettMu", r)
+
+				mu := test.Values["BarrettMu"]
+				twoToTheTwoK := new(big.Int).Lsh(big.NewInt(1), 2*k)
+				lower := new(big.Int).Mul(m, mu)
+				upper := new(big.Int).Add(mu, big.NewInt(1))
+				upper.Mul(upper, m)
+				if lower.Cmp(twoToTheTwoK) > 0 || twoToTheTwoK.Cmp(upper) >= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: BarrettMu does not satisfy M * BarrettMu <= 2 ^ (2 * K) < M * (BarrettMu + 1).\n", test.LineNumber)
+				}
+			}
+		case "ModExpBaseEqModulus":
+			if checkKeys(test, "A", "E", "M", "ModExpBaseEqModulus") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if m.Cmp(big.NewInt(1)) <= 0 || e.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: M must be greater than one and E must be non-negative.\n", test.LineNumber)
+					break
+				}
+				if new(big.Int).Mod(a, m).Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A is not a multiple of M.\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "ModExpBaseEqModulus", r)
+
+				want := big.NewInt(0)
+				if e.Sign() == 0 {
+					want.SetInt64(1)
+				}
+				checkResult(test, "0 ^ E", "ModExpBaseEqModulus", want)
+			}
+		case "MontRoundTripMul":
+			if checkKeys(test, "A", "B", "M", "R", "MontRoundTripMul") {
+				m, radix := test.Values["M"], test.Values["R"]
+				rBits, ok := checkMontRadix(test)
+				if !ok {
+					break
+				}
+
+				a := new(big.Int).Mod(test.Values["A"], m)
+				b := new(big.Int).Mod(test.Values["B"], m)
+				r := new(big.Int).Mul(a, b)
+				r.Mod(r, m)
+				checkResult(test, "A * B (mod M)", "MontRoundTripMul", r)
+
+				rr := new(big.Int).Lsh(big.NewInt(1), 2*rBits)
+				rr.Mod(rr, m)
+				checkStage := func(stage string, got, want *big.Int) bool {
+					want.Mod(want, m)
+					if got.Cmp(want) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: %s produced the wrong value.\n\tGot %s\n\tWant %s\n", test.LineNumber, stage, got.Text(16), want.Text(16))
+						return false
+					}
+					return true
+				}
+
+				aMont := montgomeryReduce(new(big.Int).Mul(a, rr), m, rBits)
+				if !checkStage("converting A to Montgomery form", aMont, new(big.Int).Mul(a, radix)) {
+					break
+				}
+				bMont := montgomeryReduce(new(big.Int).Mul(b, rr), m, rBits)
+				if !checkStage("converting B to Montgomery form", bMont, new(big.Int).Mul(b, radix)) {
+					break
+				}
+				prodMont := montgomeryReduce(new(big.Int).Mul(aMont, bMont), m, rBits)
+				want := new(big.Int).Mul(a, b)
+				if !checkStage("Montgomery multiplication", prodMont, want.Mul(want, radix)) {
+					break
+				}
+				prod := montgomeryReduce(prodMont, m, rBits)
+				checkResult(test, "converting A * B from Montgomery form", "MontRoundTripMul", prod)
+			}
+		case "ModExpChunked":
+			if checkKeys(test, "A", "E", "M", "ModExpChunked") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "ModExpChunked", r)
+
+				shift := uint(e.BitLen() / 2)
+				twoToTheS := new(big.Int).Lsh(big.NewInt(1), shift)
+				eHi := new(big.Int).Rsh(e, shift)
+				eLo := new(big.Int).Sub(e, new(big.Int).Lsh(eHi, shift))
+
+				hi := new(big.Int).Exp(a, eHi, m)
+				hi.Exp(hi, twoToTheS, m)
+				want := new(big.Int).Exp(a, new(big.Int).Lsh(eHi, shift), m)
+				if hi.Cmp(want) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: (A ^ Ehi) ^ (2 ^ s) (mod M) did not match A ^ (Ehi * 2 ^ s) (mod M).\n\tGot %s\n", test.LineNumber, hi.Text(16))
+				}
+				lo := new(big.Int).Exp(a, eLo, m)
+
+				r = new(big.Int).Mul(hi, lo)
+				r.Mod(r, m)
+				checkResult(test, "(A ^ Ehi) ^ (2 ^ s) * A ^ Elo (mod M)", "ModExpChunked", r)
+			}
+		case "GF2Add":
+			if checkKeys(test, "A", "B", "GF2Add") {
+				a, b := test.Values["A"], test.Values["B"]
+				if a.Sign() < 0 || b.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A and B must be non-negative.\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Xor(a, b)
+				checkResult(test, "A XOR B", "GF2Add", r)
+
+				bits := a.BitLen()
+				if b.BitLen() > bits {
+					bits = b.BitLen()
+				}
+				if sumBits := test.Values["GF2Add"].BitLen(); sumBits > bits {
+					fmt.Fprintf(os.Stderr, "Line %d: GF2Add has bit length %d, exceeding %d.\n", test.LineNumber, sumBits, bits)
+				}
+			}
+		case "GF2Mul":
+			if checkKeys(test, "A", "B", "GF2Mul") {
+				a, b := test.Values["A"], test.Values["B"]
+				if a.Sign() < 0 || b.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A and B must be non-negative.\n", test.LineNumber)
+					break
+				}
+
+				r := gf2Mul(a, b)
+				checkResult(test, "A * B (carry-less)", "GF2Mul", r)
+
+				// deg(X) = BitLen(X) - 1 for non-zero X.
+				if a.Sign() != 0 && b.Sign() != 0 {
+					want := a.BitLen() + b.BitLen() - 2
+					if deg := test.Values["GF2Mul"].BitLen() - 1; deg != want {
+						fmt.Fprintf(os.Stderr, "Line %d: GF2Mul has degree %d, but expected deg(A) + deg(B) = %d.\n", test.LineNumber, deg, want)
+					}
+				}
+			}
+		case "GF2Mod":
+			if checkKeys(test, "A", "P", "GF2Mod") {
+				a, p := test.Values["A"], test.Values["P"]
+				if a.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A must be non-negative.\n", test.LineNumber)
+					break
+				}
+				if p.Sign() < 0 || p.BitLen() < 2 {
+					fmt.Fprintf(os.Stderr, "Line %d: P must have positive degree.\n", test.LineNumber)
+					break
+				}
+
+				r := gf2Mod(a, p)
+				checkResult(test, "A (mod P) over GF(2)", "GF2Mod", r)
+
+				if test.Values["GF2Mod"].BitLen() >= p.BitLen() {
+					fmt.Fprintf(os.Stderr, "Line %d: GF2Mod does not have degree less than deg(P).\n", test.LineNumber)
+				}
+			}
+		case "GF2MulMod":
+			if checkKeys(test, "A", "B", "P", "GF2MulMod") {
+				a, b, p := test.Values["A"], test.Values["B"], test.Values["P"]
+				if a.Sign() < 0 || b.Sign() < 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A and B must be non-negative.\n", test.LineNumber)
+					break
+				}
+				if p.Sign() < 0 || p.BitLen() < 2 || !gf2Irreducible(p) {
+					fmt.Fprintf(os.Stderr, "Line %d: P must be irreducible with positive degree.\n", test.LineNumber)
+					break
+				}
+
+				r := gf2Mod(gf2Mul(a, b), p)
+				checkResult(test, "A * B (mod P) over GF(2)", "GF2MulMod", r)
+
+				// Process B from the most significant bit, reducing after
+				// each shift so the accumulator stays below deg(P).
+				aReduced := gf2Mod(a, p)
+				acc := new(big.Int)
+				for i := b.BitLen() - 1; i >= 0; i-- {
+					acc.Lsh(acc, 1)
+					if acc.BitLen() == p.BitLen() {
+						acc.Xor(acc, p)
+					}
+					if b.Bit(i) == 1 {
+						acc.Xor(acc, aReduced)
+					}
+					want := gf2Mod(gf2Mul(a, new(big.Int).Rsh(b, uint(i))), p)
+					if acc.Cmp(want) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: interleaved multiply-reduce diverged at bit %d.\n\tGot %s\n", test.LineNumber, i, acc.Text(16))
+						break
+					}
+				}
+				checkResult(test, "interleaved A * B (mod P) over GF(2)", "GF2MulMod", acc)
+
+				if test.Values["GF2MulMod"].BitLen() >= p.BitLen() {
+					fmt.Fprintf(os.Stderr, "Line %d: GF2MulMod does not have degree less than deg(P).\n", test.LineNumber)
+				}
+			}
+		case "ModExpTableSize":
+			if checkKeys(test, "A", "E", "M", "Window", "Mode", "TableSize", "ModExpTableSize") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+				w, ok := checkSmallValue(test, "Window", 6)
+				if !ok {
+					break
+				}
+				if w == 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Window must be at least one.\n", test.LineNumber)
+					break
+				}
+				mode, ok := checkSmallValue(test, "Mode", 1)
+				if !ok {
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "ModExpTableSize", r)
+
+				r, tableSize := windowExp(a, e, m, w, mode == 0)
+				checkResult(test, "windowed A ^ E (mod M)", "ModExpTableSize", r)
+				if want := test.Values["TableSize"]; !want.IsInt64() || want.Int64() != int64(tableSize) {
+					fmt.Fprintf(os.Stderr, "Line %d: windowed exponentiation used %d table entries, but TableSize is %s.\n", test.LineNumber, tableSize, want.Text(16))
+				}
+			}
+		case "GCDSteps":
+			if checkKeys(test, "A", "B", "GCDSteps") {
+				a, b := test.Values["A"], test.Values["B"]
+				if b.Sign() == 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: B must be non-zero.\n", test.LineNumber)
+					break
+				}
+
+				steps := euclidSteps(a, b)
+				checkResult(test, "Euclidean step count", "GCDSteps", big.NewInt(int64(steps)))
+
+				// When |A| < |B|, the first step only swaps the operands,
+				// so it does not count against Lame's bound.
+				smaller := new(big.Int).Abs(a)
+				bound := 5 * len(smaller.Text(10))
+				if bAbs := new(big.Int).Abs(b); bAbs.Cmp(smaller) < 0 {
+					bound = 5 * len(bAbs.Text(10))
+				} else if bAbs.Cmp(smaller) > 0 {
+					bound++
+				}
+				if steps > bound {
+					fmt.Fprintf(os.Stderr, "Line %d: Euclidean algorithm took %d steps, exceeding the bound of %d.\n", test.LineNumber, steps, bound)
+				}
+			}
+		case "MontModInv":
+			if checkKeys(test, "A", "M", "R", "MontModInv") {
+				m := test.Values["M"]
+				rBits, ok := checkMontRadix(test)
+				if !ok {
+					break
+				}
+
+				r := new(big.Int).ModInverse(test.Values["A"], m)
+				if r == nil {
+					fmt.Fprintf(os.Stderr, "Line %d: A has no inverse (mod M).\n", test.LineNumber)
+					break
+				}
+				checkResult(test, "A ^ -1 (mod M)", "MontModInv", r)
+
+				// Inverting A * R gives A^-1 * R^-1. REDC(A^-1 * R^-1 * R^3)
+				// then gives A^-1 * R, the inverse in Montgomery form.
+				a := new(big.Int).Mod(test.Values["A"], m)
+				aMont := new(big.Int).Lsh(a, rBits)
+				aMont.Mod(aMont, m)
+				rCubed := new(big.Int).Lsh(big.NewInt(1), 3*rBits)
+				rCubed.Mod(rCubed, m)
+				invMont := new(big.Int).ModInverse(aMont, m)
+				invMont = montgomeryReduce(invMont.Mul(invMont, rCubed), m, rBits)
+
+				want := new(big.Int).Lsh(r, rBits)
+				want.Mod(want, m)
+				if invMont.Cmp(want) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Montgomery-domain inverse did not match A ^ -1 * R (mod M).\n\tGot %s\n", test.LineNumber, invMont.Text(16))
+				}
+				r = montgomeryReduce(invMont, m, rBits)
+				checkResult(test, "Montgomery A ^ -1 (mod M)", "MontModInv", r)
+			}
+		case "ModConsistency":
+			if checkKeys(test, "A", "M1", "M2", "ModConsistency") {
+				a, m1, m2 := test.Values["A"], test.Values["M1"], test.Values["M2"]
+				if !checkPositive(test, "M1") || !checkPositive(test, "M2") {
+					break
+				}
+				if new(big.Int).Mod(m2, m1).Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: M1 does not divide M2.\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Mod(a, m1)
+				checkResult(test, "A (mod M1)", "ModConsistency", r)
+
+				r2 := new(big.Int).Mod(a, m2)
+				if r2.Sign() < 0 || r2.Cmp(m2) >= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A (mod M2) is not in [0, M2).\n\tGot %s\n", test.LineNumber, r2.Text(16))
+				}
+				r = new(big.Int).Mod(r2, m1)
+				checkResult(test, "(A (mod M2)) (mod M1)", "ModConsistency", r)
+
+				if c := test.Values["ModConsistency"]; c.Sign() < 0 || c.Cmp(m1) >= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: ModConsistency is not in [0, M1).\n", test.LineNumber)
+				}
+			}
+		case "CondSub":
+			if checkKeys(test, "A", "M", "Subtracted", "CondSub") {
+				a, m := test.Values["A"], test.Values["M"]
+				if !checkPositive(test, "M") {
+					break
+				}
+				if a.Sign() < 0 || a.Cmp(new(big.Int).Lsh(m, 1)) >= 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A must be in [0, 2M).\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Set(a)
+				subtracted := new(big.Int)
+				if a.Cmp(m) >= 0 {
+					r.Sub(r, m)
+					subtracted.SetInt64(1)
+				}
+				checkResult(test, "A >= M ? A - M : A", "CondSub", r)
+				checkResult(test, "A >= M", "Subtracted", subtracted)
+			}
+		case "ResidueSystem":
+			if checkKeys(test, "M", "ResidueSystem") {
+				m := test.Values["M"]
+				if m.Sign() <= 0 || m.Cmp(big.NewInt(1<<12)) > 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: M must be in [1, 2^12].\n", test.LineNumber)
+					break
+				}
+				list := test.Lists["ResidueSystem"]
+				if int64(len(list)) != m.Int64() {
+					fmt.Fprintf(os.Stderr, "Line %d: ResidueSystem has %d elements, but M is %d.\n", test.LineNumber, len(list), m.Int64())
+				}
+
+				seen := make([]bool, m.Int64())
+				var foundDuplicate bool
+				for _, x := range list {
+					r := new(big.Int).Mod(x, m).Int64()
+					if seen[r] && !foundDuplicate {
+						fmt.Fprintf(os.Stderr, "Line %d: ResidueSystem has duplicate residue %x (from %s).\n", test.LineNumber, r, x.Text(16))
+						foundDuplicate = true
+					}
+					seen[r] = true
+				}
+				for r, ok := range seen {
+					if !ok {
+						fmt.Fprintf(os.Stderr, "Line %d: ResidueSystem is missing residue %x.\n", test.LineNumber, r)
+						break
+					}
+				}
+			}
+		case "WNAFExp":
+			if checkKeys(test, "A", "E", "M", "W", "WNAFExp") {
+				a, e, m := test.Values["A"], test.Values["E"], test.Values["M"]
+				if !checkExponent(test, "E", 4096) || !checkPositive(test, "M") {
+					break
+				}
+				w, ok := checkSmallValue(test, "W", 6)
+				if !ok {
+					break
+				}
+				if w < 2 {
+					fmt.Fprintf(os.Stderr, "Line %d: W must be in [2, 6].\n", test.LineNumber)
+					break
+				}
+				if new(big.Int).GCD(nil, nil, a, m).Cmp(big.NewInt(1)) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A is not invertible (mod M).\n", test.LineNumber)
+					break
+				}
+
+				r := new(big.Int).Exp(a, e, m)
+				checkResult(test, "A ^ E (mod M)", "WNAFExp", r)
+				checkWNAFExp(test, "WNAFExp", w)
+			}
+		case "BatchInverseCheck":
+			if checkKeys(test, "Values", "M", "BatchInverseCheck") {
+				m, values := test.Values["M"], test.Lists["Values"]
+				if !checkPositive(test, "M") {
+					break
+				}
+				if len(values) == 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: Values must not be empty.\n", test.LineNumber)
+					break
+				}
+
+				// prefix[i] is the product of values[0] through values[i].
+				prefix := make([]*big.Int, len(values))
+				acc := new(big.Int).Mod(big.NewInt(1), m)
+				for i, v := range values {
+					acc = new(big.Int).Mul(acc, v)
+					acc.Mod(acc, m)
+					prefix[i] = acc
+				}
+
+				inv := new(big.Int).ModInverse(prefix[len(prefix)-1], m)
+				r := big.NewInt(1)
+				if inv == nil {
+					r.SetInt64(0)
+				}
+				checkResult(test, "all Values invertible (mod M)", "BatchInverseCheck", r)
+				if inv == nil {
+					if test.Values["BatchInverseCheck"].Cmp(r) != 0 {
+						for i, v := range values {
+							if new(big.Int).ModInverse(v, m) == nil {
+								fmt.Fprintf(os.Stderr, "Line %d: Values[%d] is not invertible (mod M).\n", test.LineNumber, i)
+								break
+							}
+						}
+					}
+					break
+				}
+
+				// Walk back through the prefix products, peeling off one
+				// element's inverse at a time.
+				inverses := make([]*big.Int, len(values))
+				for i := len(values) - 1; i > 0; i-- {
+					inverses[i] = new(big.Int).Mul(inv, prefix[i-1])
+					inverses[i].Mod(inverses[i], m)
+					inv.Mul(inv, values[i])
+					inv.Mod(inv, m)
+				}
+				inverses[0] = inv
+
+				one := new(big.Int).Mod(big.NewInt(1), m)
+				for i, v := range values {
+					product := new(big.Int).Mul(v, inverses[i])
+					if product.Mod(product, m).Cmp(one) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: Values[%d] * inverse (mod M) is not one.\n\tGot %s\n", test.LineNumber, i, product.Text(16))
+						break
+					}
+				}
+			}
+		case "ReduceMonotone":
+			if checkKeys(test, "A", "B", "M", "ReduceMonotone") {
+				a, b, m := test.Values["A"], test.Values["B"], test.Values["M"]
+				if !checkPositive(test, "M") {
+					break
+				}
+				if a.Cmp(b) > 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A must be at most B.\n", test.LineNumber)
+					break
+				}
+				_, rem := new(big.Int).QuoRem(new(big.Int).Sub(b, a), m, new(big.Int))
+				if rem.Sign() != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: (B - A) / M is not exact.\n", test.LineNumber)
+					break
+				}
+
+				aReduced := new(big.Int).Mod(a, m)
+				bReduced := new(big.Int).Mod(b, m)
+				if aReduced.Cmp(bReduced) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: A (mod M) did not match B (mod M).\n\tA (mod M) = %s\n\tB (mod M) = %s\n", test.LineNumber, aReduced.Text(16), bReduced.Text(16))
+				}
+				checkResult(test, "A (mod M)", "ReduceMonotone", aReduced)
+				checkResult(test, "B (mod M)", "ReduceMonotone", bReduced)
+			}
+		case "GCDSpecial":
+			if checkKeys(test, "GCDSpecial") {
+				n, ok := checkSmallValue(test, "GCDSpecial", 10000)
+				if !ok {
+					break
+				}
+				if n < 2 {
+					fmt.Fprintf(os.Stderr, "Line %d: GCDSpecial must be at least 2.\n", test.LineNumber)
+					break
+				}
+
+				// Compute fn = F(n) and fn1 = F(n + 1).
+				fn, fn1 := big.NewInt(1), big.NewInt(1)
+				for i := uint(1); i < n; i++ {
+					fn, fn1 = fn1, new(big.Int).Add(fn, fn1)
+				}
+
+				if g := new(big.Int).GCD(nil, nil, fn1, fn); g.Cmp(big.NewInt(1)) != 0 {
+					fmt.Fprintf(os.Stderr, "Line %d: gcd(F(n + 1), F(n)) is not one.\n\tGot %s\n", test.LineNumber, g.Text(16))
+				}
+				if steps := euclidSteps(fn1, fn); steps != int(n)-1 {
+					fmt.Fprintf(os.Stderr, "Line %d: Euclidean algorithm on F(n + 1), F(n) took %d steps, but expected %d.\n", test.LineNumber, steps, n-1)
+				}
+			}
+		case "MultiExp":
+			if checkKeys(test, "Bases", "Exponents", "M", "MultiExp") {
+				bases, exps, m := test.Lists["Bases"], test.Lists["Exponents"], test.Values["M"]
+				if len(bases) != len(exps) {
+					fmt.Fprintf(os.Stderr, "Line %d: Bases and Exponents have different lengths.\n", test.LineNumber)
+					break
+				}
+				if !checkPositive(test, "M") {
+					break
+				}
+				var badExp bool
+				for i, e := range exps {
+					if e.Sign() < 0 || e.BitLen() > 4096 {
+						fmt.Fprintf(os.Stderr, "Line %d: Exponents[%d] must be non-negative and at most 4096 bits.\n", test.LineNumber, i)
+						badExp = true
+					}
+				}
+				if badExp {
+					break
+				}
+
+				// Compare the simultaneous result over each prefix of the
+				// terms, so a divergence is attributed to the term that
+				// introduced it.
+				product := new(big.Int).Mod(big.NewInt(1), m)
+				for i := range bases {
+					term := new(big.Int).Exp(bases[i], exps[i], m)
+					product.Mul(product, term)
+					product.Mod(product, m)
+
+					if r := multiExp(bases[:i+1], exps[:i+1], m); r.Cmp(product) != 0 {
+						fmt.Fprintf(os.Stderr, "Line %d: simultaneous exponentiation diverged at term %d.\n\tGot %s\n", test.LineNumber, i, r.Text(16))
+						break
+					}
+				}
+				checkResult(test, "product of Bases[i] ^ Exponents[i] (mod M)", "MultiExp", product)
+
+				r := multiExp(bases, exps, m)
+				checkResult(test, "simultaneous product of Bases[i] ^ Exponents[i] (mod M)", "MultiExp", r)
+			}
 		default:
 			fmt.Fprintf(os.Stderr, "Line %d: unknown test type %q.\n", test.LineNumber, test.Type)
 		}